@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Decoder constructs an io.ReadCloser that decompresses r according to a
+// particular Content-Encoding.
+type Decoder interface {
+	New(r io.Reader) (io.ReadCloser, error)
+}
+
+// DecoderFunc adapts a plain function to the Decoder interface.
+type DecoderFunc func(r io.Reader) (io.ReadCloser, error)
+
+func (f DecoderFunc) New(r io.Reader) (io.ReadCloser, error) {
+	return f(r)
+}
+
+// decoders maps a Content-Encoding token to the Decoder that can unwrap it.
+// Registrations happen in init() so users can add formats by editing this
+// file alone.
+var decoders = map[string]Decoder{}
+
+func registerDecoder(encoding string, d Decoder) {
+	decoders[encoding] = d
+}
+
+func init() {
+	registerDecoder("gzip", DecoderFunc(func(r io.Reader) (io.ReadCloser, error) {
+		return gzip.NewReader(r)
+	}))
+
+	// "deflate" is ambiguous in the wild: RFC 2616 specifies a zlib-wrapped
+	// deflate stream, but plenty of servers send raw deflate instead. Peek
+	// at the zlib header (CMF/FLG, where the 16-bit big-endian value is a
+	// multiple of 31) to tell which one we actually got.
+	registerDecoder("deflate", DecoderFunc(func(r io.Reader) (io.ReadCloser, error) {
+		br := bufio.NewReader(r)
+
+		if peek, err := br.Peek(2); err == nil {
+			word := uint16(peek[0])<<8 | uint16(peek[1])
+			if peek[0]&0x0f == 8 && word%31 == 0 {
+				if zr, err := zlib.NewReader(br); err == nil {
+					return zr, nil
+				}
+			}
+		}
+
+		return flate.NewReader(br), nil
+	}))
+
+	registerDecoder("br", DecoderFunc(func(r io.Reader) (io.ReadCloser, error) {
+		return io.NopCloser(brotli.NewReader(r)), nil
+	}))
+
+	registerDecoder("zstd", DecoderFunc(func(r io.Reader) (io.ReadCloser, error) {
+		dec, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return dec.IOReadCloser(), nil
+	}))
+}
+
+// decodeBody wraps body with the Decoder registered for encoding, if any. It
+// returns body unchanged when encoding is empty or "identity".
+func decodeBody(encoding string, body io.Reader) (io.ReadCloser, error) {
+	encoding = strings.TrimSpace(encoding)
+
+	if encoding == "" || encoding == "identity" {
+		return io.NopCloser(body), nil
+	}
+
+	d, ok := decoders[encoding]
+	if !ok {
+		return nil, fmt.Errorf("unsupported content-encoding: %s", encoding)
+	}
+
+	return d.New(body)
+}
+
+// parseCompressList splits a comma-separated -compress flag value into the
+// Accept-Encoding tokens to advertise, skipping any that aren't registered.
+func parseCompressList(list string) []string {
+	var out []string
+	for _, token := range strings.Split(list, ",") {
+		token = strings.TrimSpace(token)
+		if token == "" {
+			continue
+		}
+		if _, ok := decoders[token]; !ok {
+			continue
+		}
+		out = append(out, token)
+	}
+	return out
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/zlib"
+	"io"
+	"testing"
+)
+
+func TestParseCompressList(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []string
+	}{
+		{"single", "gzip", []string{"gzip"}},
+		{"multiple", "gzip, br,zstd", []string{"gzip", "br", "zstd"}},
+		{"unknown dropped", "gzip,bogus,br", []string{"gzip", "br"}},
+		{"empty", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseCompressList(tt.in)
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseCompressList(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("parseCompressList(%q) = %v, want %v", tt.in, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeBodyDeflateZlibWrapped(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	if _, err := zw.Write([]byte("hello zlib-wrapped deflate")); err != nil {
+		t.Fatalf("zlib write: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("zlib close: %v", err)
+	}
+
+	r, err := decodeBody("deflate", &buf)
+	if err != nil {
+		t.Fatalf("decodeBody: %v", err)
+	}
+	defer r.Close()
+
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if string(got) != "hello zlib-wrapped deflate" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestDecodeBodyDeflateRaw(t *testing.T) {
+	var buf bytes.Buffer
+	fw, err := flate.NewWriter(&buf, flate.DefaultCompression)
+	if err != nil {
+		t.Fatalf("flate.NewWriter: %v", err)
+	}
+	if _, err := fw.Write([]byte("hello raw deflate")); err != nil {
+		t.Fatalf("flate write: %v", err)
+	}
+	if err := fw.Close(); err != nil {
+		t.Fatalf("flate close: %v", err)
+	}
+
+	r, err := decodeBody("deflate", &buf)
+	if err != nil {
+		t.Fatalf("decodeBody: %v", err)
+	}
+	defer r.Close()
+
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if string(got) != "hello raw deflate" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestDecodeBodyIdentity(t *testing.T) {
+	r, err := decodeBody("", bytes.NewBufferString("passthrough"))
+	if err != nil {
+		t.Fatalf("decodeBody: %v", err)
+	}
+	defer r.Close()
+
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if string(got) != "passthrough" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestDecodeBodyUnsupported(t *testing.T) {
+	if _, err := decodeBody("bogus", bytes.NewBufferString("x")); err == nil {
+		t.Fatal("expected an error for an unsupported encoding")
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// downloadTarget describes where a response body should be written on disk,
+// and how resume and checksum verification should be applied to it.
+type downloadTarget struct {
+	path     string
+	resume   bool
+	checksum string // "sha256:<hex>", or "" to skip verification
+}
+
+// probeAcceptRanges issues a HEAD request to see whether the server
+// advertises "Accept-Ranges: bytes" before we commit to a Range request.
+func probeAcceptRanges(client *http.Client, url string) bool {
+	req, err := http.NewRequest(http.MethodHead, url, nil)
+	if err != nil {
+		return false
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return false
+	}
+	defer res.Body.Close()
+
+	return res.Header.Get("Accept-Ranges") == "bytes"
+}
+
+// prepare opens dest for writing, issuing a Range request against req when
+// resume is enabled, a partial file already exists, and acceptRanges is
+// true. It returns the file to write into and the offset the download is
+// resuming from, if any.
+func (d downloadTarget) prepare(req *http.Request, acceptRanges bool) (*os.File, int64, error) {
+	if !d.resume || !acceptRanges {
+		f, err := os.Create(d.path)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to create output file: %w", err)
+		}
+		return f, 0, nil
+	}
+
+	info, err := os.Stat(d.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return nil, 0, fmt.Errorf("failed to stat output file: %w", err)
+		}
+		f, err := os.Create(d.path)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to create output file: %w", err)
+		}
+		return f, 0, nil
+	}
+
+	offset := info.Size()
+	if offset == 0 {
+		f, err := os.Create(d.path)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to create output file: %w", err)
+		}
+		return f, 0, nil
+	}
+
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+
+	f, err := os.OpenFile(d.path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open output file for resume: %w", err)
+	}
+
+	return f, offset, nil
+}
+
+// writeBody streams res.Body into f, truncating f first if the server
+// ignored the Range request (200 OK) despite a nonzero offset. It returns
+// the number of bytes written and, if a checksum was requested, a
+// verification error when the digest doesn't match.
+func (d downloadTarget) writeBody(res *http.Response, f *os.File, offset int64, reporter *Reporter, verbose bool) (int64, error) {
+	if offset > 0 && res.StatusCode == http.StatusOK {
+		if verbose {
+			reporter.Report("RESUME: server ignored Range, restarting from 0")
+		}
+		if err := f.Truncate(0); err != nil {
+			return 0, fmt.Errorf("failed to truncate output file: %w", err)
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return 0, fmt.Errorf("failed to seek output file: %w", err)
+		}
+		offset = 0
+	}
+
+	if offset > 0 && res.StatusCode != http.StatusPartialContent {
+		return 0, fmt.Errorf("resume requested but server returned %d instead of 206", res.StatusCode)
+	}
+
+	if verbose {
+		remaining := "unknown"
+		if res.ContentLength >= 0 {
+			remaining = fmt.Sprintf("%d", res.ContentLength)
+		}
+		reporter.Reportf("RESUME: offset=%d bytes_remaining=%s", offset, remaining)
+	}
+
+	hasher := sha256.New()
+	tee := io.TeeReader(res.Body, hasher)
+	var written int64
+
+	buf := make([]byte, 16*1024)
+	for {
+		n, err := tee.Read(buf)
+		if n > 0 {
+			if _, werr := f.Write(buf[:n]); werr != nil {
+				return written, fmt.Errorf("failed to write output file: %w", werr)
+			}
+			written += int64(n)
+
+			if verbose {
+				reporter.Reportf("CHUNK: bytes=%d", n)
+			}
+		}
+
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return written, fmt.Errorf("failed to read response body: %w", err)
+		}
+	}
+
+	if d.checksum == "" {
+		return written, nil
+	}
+
+	return written, d.verify(f, offset, hasher)
+}
+
+// verify checks the requested checksum against the downloaded content. When
+// resuming a partial file, the digest has to be computed over the whole
+// file rather than just the bytes written this run, so it re-reads from
+// disk.
+func (d downloadTarget) verify(f *os.File, offset int64, tail hash.Hash) error {
+	algo, want, ok := strings.Cut(d.checksum, ":")
+	if !ok || algo != "sha256" {
+		return fmt.Errorf("unsupported -checksum format %q, want sha256:<hex>", d.checksum)
+	}
+
+	var got string
+	if offset == 0 {
+		got = hex.EncodeToString(tail.Sum(nil))
+	} else {
+		h := sha256.New()
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("failed to seek output file for checksum: %w", err)
+		}
+		if _, err := io.Copy(h, f); err != nil {
+			return fmt.Errorf("failed to hash output file: %w", err)
+		}
+		got = hex.EncodeToString(h.Sum(nil))
+	}
+
+	if !strings.EqualFold(got, want) {
+		return fmt.Errorf("checksum mismatch: want %s, got %s", want, got)
+	}
+
+	return nil
+}
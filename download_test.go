@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDownloadTargetWriteBodyChecksum(t *testing.T) {
+	data := []byte("the quick brown fox jumps over the lazy dog")
+	sum := sha256.Sum256(data)
+	want := hex.EncodeToString(sum[:])
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.bin")
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	defer f.Close()
+
+	res := &http.Response{
+		StatusCode:    http.StatusOK,
+		ContentLength: int64(len(data)),
+		Body:          io.NopCloser(bytes.NewReader(data)),
+	}
+
+	dl := downloadTarget{path: path, checksum: "sha256:" + want}
+
+	reporter := &Reporter{}
+	written, err := dl.writeBody(res, f, 0, reporter, false)
+	if err != nil {
+		t.Fatalf("writeBody: %v", err)
+	}
+	if written != int64(len(data)) {
+		t.Fatalf("written = %d, want %d", written, len(data))
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read output file: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatalf("output file content = %q, want %q", got, data)
+	}
+}
+
+func TestDownloadTargetWriteBodyChecksumMismatch(t *testing.T) {
+	data := []byte("some bytes")
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.bin")
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	defer f.Close()
+
+	res := &http.Response{
+		StatusCode:    http.StatusOK,
+		ContentLength: int64(len(data)),
+		Body:          io.NopCloser(bytes.NewReader(data)),
+	}
+
+	dl := downloadTarget{path: path, checksum: "sha256:" + hex.EncodeToString(make([]byte, sha256.Size))}
+
+	reporter := &Reporter{}
+	if _, err := dl.writeBody(res, f, 0, reporter, false); err == nil {
+		t.Fatal("expected a checksum mismatch error")
+	}
+}
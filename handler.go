@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/itchyny/gojq"
+)
+
+// StreamHandler consumes response body bytes as they arrive off the wire.
+// Exactly one handler drives a given response body: raw passthrough to
+// stdout, or NDJSON record decoding. HandleChunk is called once per read
+// from the body; Flush is called once after the body is exhausted so a
+// handler can finish buffered work and report any decode error.
+type StreamHandler interface {
+	HandleChunk(b []byte) error
+	Flush() error
+}
+
+// rawHandler writes chunks straight through to stdout and reports per-chunk
+// timing, the original behavior of the body-read loop.
+type rawHandler struct {
+	w        io.Writer
+	reporter *Reporter
+	verbose  bool
+}
+
+func (h *rawHandler) HandleChunk(b []byte) error {
+	if _, err := h.w.Write(b); err != nil {
+		return err
+	}
+
+	if h.verbose {
+		h.reporter.Reportf("CHUNK: bytes=%d", len(b))
+	}
+
+	return nil
+}
+
+func (h *rawHandler) Flush() error { return nil }
+
+// jsonHandler decodes the body as a stream of JSON values (NDJSON, or any
+// sequence of whitespace-separated top-level values), optionally filters
+// each one through a gojq query, and pretty-prints the result. Chunks are
+// fed to the decoder through an io.Pipe so json.Decoder can block for more
+// bytes instead of erroring out on a value split across reads.
+type jsonHandler struct {
+	pw   *io.PipeWriter
+	done chan struct{}
+	err  error
+}
+
+func newJSONHandler(w io.Writer, jqExpr string, reporter *Reporter, verbose bool) (*jsonHandler, error) {
+	var query *gojq.Query
+	if jqExpr != "" {
+		q, err := gojq.Parse(jqExpr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -jq expression: %w", err)
+		}
+		query = q
+	}
+
+	pr, pw := io.Pipe()
+	h := &jsonHandler{pw: pw, done: make(chan struct{})}
+
+	go h.decodeLoop(pr, w, query, reporter, verbose)
+
+	return h, nil
+}
+
+func (h *jsonHandler) decodeLoop(pr *io.PipeReader, w io.Writer, query *gojq.Query, reporter *Reporter, verbose bool) {
+	defer close(h.done)
+
+	dec := json.NewDecoder(pr)
+
+	for {
+		var record interface{}
+		if err := dec.Decode(&record); err != nil {
+			if err != io.EOF {
+				h.err = fmt.Errorf("failed to decode JSON record: %w", err)
+			}
+			pr.CloseWithError(err)
+			return
+		}
+
+		out := record
+		if query != nil {
+			iter := query.Run(record)
+			v, ok := iter.Next()
+			if !ok {
+				continue
+			}
+			if err, ok := v.(error); ok {
+				h.err = fmt.Errorf("-jq expression failed: %w", err)
+				pr.CloseWithError(h.err)
+				return
+			}
+			out = v
+		}
+
+		enc, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			h.err = fmt.Errorf("failed to marshal JSON record: %w", err)
+			pr.CloseWithError(h.err)
+			return
+		}
+
+		fmt.Fprintln(w, string(enc))
+
+		if verbose {
+			reporter.Reportf("RECORD: bytes=%d fields=%d", len(enc), fieldCount(out))
+		}
+	}
+}
+
+func (h *jsonHandler) HandleChunk(b []byte) error {
+	_, err := h.pw.Write(b)
+	return err
+}
+
+func (h *jsonHandler) Flush() error {
+	h.pw.Close()
+	<-h.done
+	return h.err
+}
+
+// fieldCount reports how many fields or elements a decoded JSON value has,
+// for the RECORD reporter line.
+func fieldCount(v interface{}) int {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		return len(t)
+	case []interface{}:
+		return len(t)
+	default:
+		return 1
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRawHandler(t *testing.T) {
+	var buf bytes.Buffer
+	h := &rawHandler{w: &buf, reporter: &Reporter{}, verbose: false}
+
+	if err := h.HandleChunk([]byte("hello ")); err != nil {
+		t.Fatalf("HandleChunk: %v", err)
+	}
+	if err := h.HandleChunk([]byte("world")); err != nil {
+		t.Fatalf("HandleChunk: %v", err)
+	}
+	if err := h.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	if buf.String() != "hello world" {
+		t.Fatalf("got %q", buf.String())
+	}
+}
+
+func TestJSONHandlerDecodesNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	h, err := newJSONHandler(&buf, "", &Reporter{}, false)
+	if err != nil {
+		t.Fatalf("newJSONHandler: %v", err)
+	}
+
+	input := "{\"a\":1}\n{\"b\":2}\n"
+	if err := h.HandleChunk([]byte(input)); err != nil {
+		t.Fatalf("HandleChunk: %v", err)
+	}
+	if err := h.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "\"a\": 1") {
+		t.Fatalf("expected first record in output, got %q", out)
+	}
+	if !strings.Contains(out, "\"b\": 2") {
+		t.Fatalf("expected second record in output, got %q", out)
+	}
+}
+
+func TestJSONHandlerAppliesJQFilter(t *testing.T) {
+	var buf bytes.Buffer
+	h, err := newJSONHandler(&buf, ".a", &Reporter{}, false)
+	if err != nil {
+		t.Fatalf("newJSONHandler: %v", err)
+	}
+
+	if err := h.HandleChunk([]byte("{\"a\":42,\"b\":2}\n")); err != nil {
+		t.Fatalf("HandleChunk: %v", err)
+	}
+	if err := h.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	if strings.TrimSpace(buf.String()) != "42" {
+		t.Fatalf("got %q, want 42", buf.String())
+	}
+}
+
+func TestJSONHandlerInvalidJQExpression(t *testing.T) {
+	if _, err := newJSONHandler(&bytes.Buffer{}, "(((", &Reporter{}, false); err == nil {
+		t.Fatal("expected an error for an invalid -jq expression")
+	}
+}
+
+func TestJSONHandlerInvalidJSON(t *testing.T) {
+	var buf bytes.Buffer
+	h, err := newJSONHandler(&buf, "", &Reporter{}, false)
+	if err != nil {
+		t.Fatalf("newJSONHandler: %v", err)
+	}
+
+	if err := h.HandleChunk([]byte("not json")); err != nil {
+		t.Fatalf("HandleChunk: %v", err)
+	}
+	if err := h.Flush(); err == nil {
+		t.Fatal("expected Flush to surface the decode error")
+	}
+}
+
+func TestFieldCount(t *testing.T) {
+	if got := fieldCount(map[string]interface{}{"a": 1, "b": 2}); got != 2 {
+		t.Fatalf("got %d, want 2", got)
+	}
+	if got := fieldCount([]interface{}{1, 2, 3}); got != 3 {
+		t.Fatalf("got %d, want 3", got)
+	}
+	if got := fieldCount(42); got != 1 {
+		t.Fatalf("got %d, want 1", got)
+	}
+}
@@ -1,13 +1,15 @@
 package main
 
 import (
-	"compress/gzip"
+	"context"
 	"flag"
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptrace"
 	"net/url"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/fatih/color"
@@ -47,7 +49,7 @@ func (r *Reporter) Reportf(format string, a ...interface{}) {
 	r.Report(fmt.Sprintf(format, a...))
 }
 
-func run(verbose, noColor, compress bool) error {
+func run(verbose, noColor, sse, resume, jsonMode, stdinMode bool, compress, httpProto, output, checksum, jq string) error {
 	// If the verbose flag is not set, disable color output.
 	if noColor {
 		color.NoColor = true
@@ -67,6 +69,16 @@ func run(verbose, noColor, compress bool) error {
 		return fmt.Errorf("invalid URL: %w", err)
 	}
 
+	// A ws:// or wss:// URL skips the HTTP request/response flow entirely
+	// and hands off to the WebSocket upgrade and frame loop.
+	if isWebSocketURL(target) {
+		reporter := Reporter{}
+		if verbose {
+			reporter.Start()
+		}
+		return streamWebSocket(context.Background(), target, stdinMode, &reporter, verbose)
+	}
+
 	// Create a new request to the target URL.
 	req, err := http.NewRequest("GET", target.String(), nil)
 	if err != nil {
@@ -75,20 +87,50 @@ func run(verbose, noColor, compress bool) error {
 
 	req.Header.Set("Connection", "keep-alive")
 
-	// If the compress flag is set, add the Accept-Encoding header to the
-	// request.
-	if compress {
-		req.Header.Set("Accept-Encoding", "gzip")
+	// If SSE mode was requested explicitly, say so up front; auto-detection
+	// still applies based on the response Content-Type either way.
+	if sse {
+		req.Header.Set("Accept", "text/event-stream")
+	}
+
+	// If the compress flag is set, advertise the requested encodings via the
+	// Accept-Encoding header so the server can pick one to respond with.
+	if compress != "" {
+		if encodings := parseCompressList(compress); len(encodings) > 0 {
+			req.Header.Set("Accept-Encoding", strings.Join(encodings, ", "))
+		}
+	}
+
+	client, err := buildClient(httpProto)
+	if err != nil {
+		return err
+	}
+
+	var dl *downloadTarget
+	var dlFile *os.File
+	var dlOffset int64
+
+	if output != "" {
+		dl = &downloadTarget{path: output, resume: resume, checksum: checksum}
+
+		acceptRanges := resume && probeAcceptRanges(client, target.String())
+
+		dlFile, dlOffset, err = dl.prepare(req, acceptRanges)
+		if err != nil {
+			return err
+		}
+		defer dlFile.Close()
 	}
 
 	reporter := Reporter{}
 
 	if verbose {
 		reporter.Start()
+		req = req.WithContext(httptrace.WithClientTrace(req.Context(), clientTrace(&reporter, httpProto)))
 	}
 
 	// Send the request and get the response.
-	res, err := http.DefaultClient.Do(req)
+	res, err := client.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to send request: %w", err)
 	}
@@ -108,15 +150,49 @@ func run(verbose, noColor, compress bool) error {
 		reporter.Report("HEADERS")
 	}
 
-	var reader io.Reader = res.Body
+	// If an output file was requested, stream the body to disk (honoring
+	// resume and checksum verification) instead of the raw stdout loop.
+	if dl != nil {
+		written, err := dl.writeBody(res, dlFile, dlOffset, &reporter, verbose)
+		if err != nil {
+			return err
+		}
+
+		if verbose {
+			reporter.Reportf("END: bytes_written=%d", written)
+		}
+
+		return nil
+	}
+
+	// If the server identified this as an event stream, or the caller asked
+	// for SSE framing explicitly, hand the body off to the SSE decoder
+	// instead of the raw chunked loop below.
+	if sse || isSSEContentType(res) {
+		return streamSSE(client, req, res, &reporter, verbose)
+	}
 
-	// If the response is gzip encoded, create a new gzip reader to decompress
-	// the response body.
-	if res.Header.Get("Content-Encoding") == "gzip" {
-		reader, err = gzip.NewReader(res.Body)
+	// Decode the body according to its Content-Encoding, if any. decodeBody
+	// falls back to the raw body for "identity" or an empty header.
+	decoded, err := decodeBody(res.Header.Get("Content-Encoding"), res.Body)
+	if err != nil {
+		return fmt.Errorf("failed to decode response body: %w", err)
+	}
+	defer decoded.Close()
+
+	var reader io.Reader = decoded
+
+	// Pick the handler that turns body bytes into output: NDJSON records
+	// when -json was requested, otherwise raw passthrough to stdout.
+	var handler StreamHandler
+	if jsonMode {
+		h, err := newJSONHandler(os.Stdout, jq, &reporter, verbose)
 		if err != nil {
-			return fmt.Errorf("failed to create gzip reader: %w", err)
+			return err
 		}
+		handler = h
+	} else {
+		handler = &rawHandler{w: os.Stdout, reporter: &reporter, verbose: verbose}
 	}
 
 	// Print the response body as it comes in without waiting for the full
@@ -125,12 +201,8 @@ func run(verbose, noColor, compress bool) error {
 	for {
 		n, err := reader.Read(buf)
 		if n > 0 {
-			if _, err := os.Stdout.Write(buf[:n]); err != nil {
-				return fmt.Errorf("failed to write response body: %w", err)
-			}
-
-			if verbose {
-				reporter.Reportf("CHUNK: bytes=%d", n)
+			if herr := handler.HandleChunk(buf[:n]); herr != nil {
+				return fmt.Errorf("failed to handle response chunk: %w", herr)
 			}
 		}
 
@@ -143,6 +215,10 @@ func run(verbose, noColor, compress bool) error {
 		}
 	}
 
+	if err := handler.Flush(); err != nil {
+		return err
+	}
+
 	if verbose {
 		reporter.Report("END")
 	}
@@ -152,14 +228,30 @@ func run(verbose, noColor, compress bool) error {
 
 func main() {
 	var noColor bool
-	var compress bool
+	var sse bool
+	var resume bool
+	var jsonMode bool
+	var stdinMode bool
+	var compress string
+	var httpProto string
+	var output string
+	var checksum string
+	var jq string
 
 	flag.BoolVar(&noColor, "no-color", false, "disable color output")
-	flag.BoolVar(&compress, "compress", false, "request gzip response")
+	flag.BoolVar(&sse, "sse", false, "decode the response as Server-Sent Events, reconnecting on disconnect")
+	flag.BoolVar(&resume, "resume", false, "resume -output from its current size via a Range request")
+	flag.BoolVar(&jsonMode, "json", false, "decode the response as a stream of JSON records (NDJSON)")
+	flag.BoolVar(&stdinMode, "stdin", false, "for ws:// and wss:// URLs, relay stdin lines as outgoing text frames")
+	flag.StringVar(&compress, "compress", "", "comma-separated Accept-Encoding list to request, e.g. gzip,br,deflate,zstd")
+	flag.StringVar(&httpProto, "http", "auto", "HTTP protocol to use: 1, 2, 3, or auto")
+	flag.StringVar(&output, "output", "", "write the response body to this file instead of stdout")
+	flag.StringVar(&checksum, "checksum", "", "verify -output against this digest, e.g. sha256:<hex>")
+	flag.StringVar(&jq, "jq", "", "jq expression to filter each -json record through")
 
 	flag.Parse()
 
-	if err := run(true, noColor, compress); err != nil {
+	if err := run(true, noColor, sse, resume, jsonMode, stdinMode, compress, httpProto, output, checksum, jq); err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
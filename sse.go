@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sseEvent is a single decoded Server-Sent Event.
+type sseEvent struct {
+	Event string
+	ID    string
+	Data  string
+	Retry time.Duration
+}
+
+// sseRecord is the structured line emitted to stdout for each decoded event.
+type sseRecord struct {
+	Event       string    `json:"event,omitempty"`
+	ID          string    `json:"id,omitempty"`
+	Data        string    `json:"data"`
+	ReceivedAt  time.Time `json:"received_at"`
+	SinceLastMs int64     `json:"since_last_ms"`
+}
+
+// isSSEContentType reports whether the response advertises SSE framing, for
+// auto-detecting -sse mode when the flag wasn't passed explicitly.
+func isSSEContentType(res *http.Response) bool {
+	return strings.HasPrefix(res.Header.Get("Content-Type"), "text/event-stream")
+}
+
+// readSSEEvents scans body for \n\n-delimited SSE events, reassembling
+// multi-line "data:" fields, and invokes emit for each event it decodes.
+func readSSEEvents(body io.Reader, emit func(sseEvent)) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var ev sseEvent
+	var data []string
+	hasContent := false
+
+	flush := func() {
+		if !hasContent {
+			return
+		}
+		ev.Data = strings.Join(data, "\n")
+		emit(ev)
+		ev = sseEvent{}
+		data = nil
+		hasContent = false
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if line == "" {
+			flush()
+			continue
+		}
+
+		hasContent = true
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+
+		switch field {
+		case "event":
+			ev.Event = value
+		case "id":
+			ev.ID = value
+		case "data":
+			data = append(data, value)
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil {
+				ev.Retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+
+	flush()
+
+	return scanner.Err()
+}
+
+// streamSSE decodes res as a Server-Sent Events stream and prints each event
+// as a structured JSON line. If the connection drops mid-stream, or once the
+// initial body is exhausted, it reconnects with req using the last seen
+// event ID in the Last-Event-ID header and an exponential backoff, honoring
+// any server-advertised retry interval.
+func streamSSE(client *http.Client, req *http.Request, res *http.Response, reporter *Reporter, verbose bool) error {
+	const initialBackoff = 1 * time.Second
+	const maxBackoff = 30 * time.Second
+
+	backoff := initialBackoff
+	var lastEventID string
+	last := time.Now()
+
+	for {
+		// res is nil after a failed reconnect attempt below; skip straight
+		// to the backoff wait and retry instead of dereferencing it.
+		if res != nil {
+			streamErr := readSSEEvents(res.Body, func(ev sseEvent) {
+				now := time.Now()
+
+				if ev.ID != "" {
+					lastEventID = ev.ID
+				}
+				if ev.Retry > 0 {
+					backoff = ev.Retry
+				}
+
+				rec := sseRecord{
+					Event:       ev.Event,
+					ID:          ev.ID,
+					Data:        ev.Data,
+					ReceivedAt:  now,
+					SinceLastMs: now.Sub(last).Milliseconds(),
+				}
+				last = now
+
+				if enc, err := json.Marshal(rec); err == nil {
+					fmt.Fprintln(os.Stdout, string(enc))
+				}
+
+				if verbose {
+					reporter.Reportf("EVENT: event=%s id=%s bytes=%d", ev.Event, ev.ID, len(ev.Data))
+				}
+			})
+			res.Body.Close()
+			res = nil
+
+			if streamErr != nil && verbose {
+				reporter.Reportf("SSE_RECONNECT: error=%v backoff=%s", streamErr, backoff)
+			} else if verbose {
+				reporter.Reportf("SSE_RECONNECT: stream closed, backoff=%s", backoff)
+			}
+		}
+
+		select {
+		case <-req.Context().Done():
+			return req.Context().Err()
+		case <-time.After(backoff):
+		}
+
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+
+		var err error
+		res, err = client.Do(req)
+		if err != nil {
+			if verbose {
+				reporter.Reportf("SSE_RECONNECT: error=%v backoff=%s", err, backoff)
+			}
+			continue
+		}
+
+		if res.StatusCode != http.StatusOK {
+			res.Body.Close()
+			return fmt.Errorf("unexpected SSE status on reconnect: %d %s", res.StatusCode, http.StatusText(res.StatusCode))
+		}
+	}
+}
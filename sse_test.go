@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadSSEEventsBasic(t *testing.T) {
+	body := "event: greeting\nid: 1\ndata: hello\n\ndata: world\n\n"
+
+	var got []sseEvent
+	if err := readSSEEvents(strings.NewReader(body), func(ev sseEvent) {
+		got = append(got, ev)
+	}); err != nil {
+		t.Fatalf("readSSEEvents: %v", err)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("got %d events, want 2: %+v", len(got), got)
+	}
+
+	if got[0].Event != "greeting" || got[0].ID != "1" || got[0].Data != "hello" {
+		t.Fatalf("got[0] = %+v", got[0])
+	}
+	if got[1].Event != "" || got[1].ID != "" || got[1].Data != "world" {
+		t.Fatalf("got[1] = %+v", got[1])
+	}
+}
+
+func TestReadSSEEventsMultilineData(t *testing.T) {
+	body := "data: line one\ndata: line two\n\n"
+
+	var got []sseEvent
+	if err := readSSEEvents(strings.NewReader(body), func(ev sseEvent) {
+		got = append(got, ev)
+	}); err != nil {
+		t.Fatalf("readSSEEvents: %v", err)
+	}
+
+	if len(got) != 1 {
+		t.Fatalf("got %d events, want 1", len(got))
+	}
+	if got[0].Data != "line one\nline two" {
+		t.Fatalf("got[0].Data = %q", got[0].Data)
+	}
+}
+
+func TestReadSSEEventsRetry(t *testing.T) {
+	body := "retry: 5000\ndata: x\n\n"
+
+	var got sseEvent
+	if err := readSSEEvents(strings.NewReader(body), func(ev sseEvent) {
+		got = ev
+	}); err != nil {
+		t.Fatalf("readSSEEvents: %v", err)
+	}
+
+	if got.Retry != 5*time.Second {
+		t.Fatalf("got.Retry = %v, want 5s", got.Retry)
+	}
+}
+
+func TestReadSSEEventsTrailingEventWithoutBlankLine(t *testing.T) {
+	body := "data: no trailing blank line"
+
+	var got []sseEvent
+	if err := readSSEEvents(strings.NewReader(body), func(ev sseEvent) {
+		got = append(got, ev)
+	}); err != nil {
+		t.Fatalf("readSSEEvents: %v", err)
+	}
+
+	if len(got) != 1 || got[0].Data != "no trailing blank line" {
+		t.Fatalf("got = %+v", got)
+	}
+}
+
+// alwaysErrRoundTripper simulates a reconnect attempt that always fails
+// (DNS blip, connection refused, etc).
+type alwaysErrRoundTripper struct{ err error }
+
+func (rt alwaysErrRoundTripper) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, rt.err
+}
+
+func TestStreamSSESurvivesFailedReconnect(t *testing.T) {
+	client := &http.Client{Transport: alwaysErrRoundTripper{err: errors.New("connection refused")}}
+
+	// initialBackoff in sse.go is 1s, so the deadline needs to outlive one
+	// failed reconnect attempt (which loops back to the top of streamSSE
+	// immediately) but not the doubled backoff after it.
+	ctx, cancel := context.WithTimeout(context.Background(), 1300*time.Millisecond)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://example.invalid/stream", nil)
+	if err != nil {
+		t.Fatalf("NewRequestWithContext: %v", err)
+	}
+
+	res := &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(""))}
+
+	// Before the fix, the reconnect attempt inside streamSSE would set res
+	// to nil on error and loop back into readSSEEvents(res.Body, ...),
+	// panicking with a nil pointer dereference instead of retrying.
+	err = streamSSE(client, req, res, &Reporter{}, false)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("streamSSE() error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestIsSSEContentType(t *testing.T) {
+	res := &http.Response{Header: http.Header{"Content-Type": []string{"text/event-stream; charset=utf-8"}}}
+	if !isSSEContentType(res) {
+		t.Fatal("expected text/event-stream to be detected")
+	}
+
+	res = &http.Response{Header: http.Header{"Content-Type": []string{"application/json"}}}
+	if isSSEContentType(res) {
+		t.Fatal("did not expect application/json to be detected as SSE")
+	}
+}
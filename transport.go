@@ -0,0 +1,84 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/http/httptrace"
+
+	"golang.org/x/net/http2"
+
+	"github.com/quic-go/quic-go/http3"
+)
+
+// buildClient returns an *http.Client whose RoundTripper is pinned to the
+// requested HTTP protocol version. proto is one of "1", "2", "3", or "auto".
+func buildClient(proto string) (*http.Client, error) {
+	switch proto {
+	case "1":
+		return &http.Client{
+			Transport: &http.Transport{
+				ForceAttemptHTTP2: false,
+				TLSClientConfig:   &tls.Config{NextProtos: []string{"http/1.1"}},
+			},
+		}, nil
+
+	case "2":
+		tr := &http.Transport{}
+		if err := http2.ConfigureTransport(tr); err != nil {
+			return nil, fmt.Errorf("failed to configure http2 transport: %w", err)
+		}
+		return &http.Client{Transport: tr}, nil
+
+	case "3":
+		return &http.Client{Transport: &http3.Transport{}}, nil
+
+	case "auto", "":
+		return http.DefaultClient, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported -http value %q, want 1, 2, 3, or auto", proto)
+	}
+}
+
+// clientTrace builds an httptrace.ClientTrace that reports connection and
+// handshake milestones through reporter, tagged with the -http value in use
+// so the CHUNK timeline is comparable across protocols instead of producing
+// identical-looking output for h1, h2, and h3. For h3, net/http/httptrace
+// has no hook for QUIC-level 0-RTT/1-RTT; tls.ConnectionState.DidResume is
+// the closest observable proxy (a resumed TLS 1.3 session is what makes
+// 0-RTT possible), so the TLS event is labeled from that.
+func clientTrace(reporter *Reporter, proto string) *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			reporter.Reportf("CONN proto=%s: reused=%t idle=%s", proto, info.Reused, info.IdleTime)
+		},
+		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
+			if err != nil {
+				reporter.Reportf("TLS proto=%s: error=%v", proto, err)
+				return
+			}
+
+			if proto == "3" {
+				rtt := "1-RTT"
+				if state.DidResume {
+					rtt = "0-RTT"
+				}
+				reporter.Reportf("TLS proto=%s rtt=%s: alpn=%s version=%x", proto, rtt, state.NegotiatedProtocol, state.Version)
+				return
+			}
+
+			reporter.Reportf("TLS proto=%s: alpn=%s version=%x", proto, state.NegotiatedProtocol, state.Version)
+		},
+		WroteRequest: func(info httptrace.WroteRequestInfo) {
+			if info.Err != nil {
+				reporter.Reportf("HEADERS_SENT proto=%s: error=%v", proto, info.Err)
+				return
+			}
+			reporter.Reportf("HEADERS_SENT proto=%s", proto)
+		},
+		GotFirstResponseByte: func() {
+			reporter.Reportf("DATA proto=%s", proto)
+		},
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go/http3"
+)
+
+func TestBuildClientHTTP1(t *testing.T) {
+	client, err := buildClient("1")
+	if err != nil {
+		t.Fatalf("buildClient(1): %v", err)
+	}
+
+	tr, ok := client.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("Transport = %T, want *http.Transport", client.Transport)
+	}
+	if tr.ForceAttemptHTTP2 {
+		t.Fatal("expected ForceAttemptHTTP2=false for -http=1")
+	}
+}
+
+func TestBuildClientHTTP2(t *testing.T) {
+	client, err := buildClient("2")
+	if err != nil {
+		t.Fatalf("buildClient(2): %v", err)
+	}
+	if _, ok := client.Transport.(*http.Transport); !ok {
+		t.Fatalf("Transport = %T, want *http.Transport configured for h2", client.Transport)
+	}
+}
+
+func TestBuildClientHTTP3(t *testing.T) {
+	client, err := buildClient("3")
+	if err != nil {
+		t.Fatalf("buildClient(3): %v", err)
+	}
+	if _, ok := client.Transport.(*http3.Transport); !ok {
+		t.Fatalf("Transport = %T, want *http3.Transport", client.Transport)
+	}
+}
+
+func TestBuildClientAuto(t *testing.T) {
+	for _, proto := range []string{"auto", ""} {
+		client, err := buildClient(proto)
+		if err != nil {
+			t.Fatalf("buildClient(%q): %v", proto, err)
+		}
+		if client != http.DefaultClient {
+			t.Fatalf("buildClient(%q) did not return http.DefaultClient", proto)
+		}
+	}
+}
+
+func TestBuildClientUnsupported(t *testing.T) {
+	if _, err := buildClient("9"); err == nil {
+		t.Fatal("expected an error for an unsupported -http value")
+	}
+}
+
+func TestClientTraceReportsThroughReporter(t *testing.T) {
+	reporter := &Reporter{}
+	reporter.Start()
+
+	trace := clientTrace(reporter, "3")
+
+	calls := []func(){
+		func() { trace.GotConn(httptrace.GotConnInfo{Reused: true}) },
+		func() {
+			trace.TLSHandshakeDone(tls.ConnectionState{DidResume: true, NegotiatedProtocol: "h3"}, nil)
+		},
+		func() { trace.WroteRequest(httptrace.WroteRequestInfo{}) },
+		func() { trace.GotFirstResponseByte() },
+	}
+
+	for _, call := range calls {
+		before := reporter.last
+		time.Sleep(time.Millisecond)
+		call()
+		if !reporter.last.After(before) {
+			t.Fatal("expected the trace hook to report through reporter")
+		}
+	}
+}
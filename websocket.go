@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// writeWait bounds how long a control frame reply (pong, close ack) is
+// allowed to take to write back out.
+const writeWait = 10 * time.Second
+
+// isWebSocketURL reports whether target uses the ws:// or wss:// scheme.
+func isWebSocketURL(target *url.URL) bool {
+	return target.Scheme == "ws" || target.Scheme == "wss"
+}
+
+// streamWebSocket upgrades target to a WebSocket connection, prints the 101
+// handshake headers the way the HTTP path prints response headers, then
+// streams frames in both directions: inbound frames to stdout, and (when
+// stdinMode is set) stdin lines as outgoing text frames. Ping, pong, and
+// close frames are reported explicitly via gorilla/websocket's control
+// frame handlers.
+func streamWebSocket(ctx context.Context, target *url.URL, stdinMode bool, reporter *Reporter, verbose bool) error {
+	conn, res, err := websocket.DefaultDialer.DialContext(ctx, target.String(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to upgrade websocket connection: %w", err)
+	}
+	defer conn.Close()
+
+	cyan(os.Stdout, "%s %d %s\n", res.Proto, res.StatusCode, http.StatusText(res.StatusCode))
+	for k, v := range res.Header {
+		for _, vv := range v {
+			cyan(os.Stdout, "%s", k)
+			fmt.Fprintf(os.Stdout, ": %s\n", vv)
+		}
+	}
+	fmt.Println()
+
+	if verbose {
+		reporter.Report("HANDSHAKE")
+	}
+
+	conn.SetPingHandler(func(appData string) error {
+		if verbose {
+			reporter.Reportf("FRAME: op=ping bytes=%d", len(appData))
+		}
+		return conn.WriteControl(websocket.PongMessage, []byte(appData), time.Now().Add(writeWait))
+	})
+
+	conn.SetPongHandler(func(appData string) error {
+		if verbose {
+			reporter.Reportf("FRAME: op=pong bytes=%d", len(appData))
+		}
+		return nil
+	})
+
+	conn.SetCloseHandler(func(code int, text string) error {
+		if verbose {
+			reporter.Reportf("FRAME: op=close code=%d reason=%q", code, text)
+		}
+		deadline := time.Now().Add(writeWait)
+		return conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, ""), deadline)
+	})
+
+	if stdinMode {
+		go sendStdinFrames(conn)
+	}
+
+	for {
+		typ, data, err := conn.ReadMessage()
+		if err != nil {
+			var closeErr *websocket.CloseError
+			if errors.As(err, &closeErr) {
+				// The close handler above already reported this frame.
+				return nil
+			}
+			return fmt.Errorf("failed to read websocket frame: %w", err)
+		}
+
+		op := "binary"
+		if typ == websocket.TextMessage {
+			op = "text"
+		}
+
+		if _, err := os.Stdout.Write(data); err != nil {
+			return fmt.Errorf("failed to write frame: %w", err)
+		}
+		fmt.Println()
+
+		if verbose {
+			reporter.Reportf("FRAME: op=%s bytes=%d", op, len(data))
+		}
+	}
+}
+
+// sendStdinFrames relays each line read from stdin as an outgoing text
+// frame until stdin closes or the write fails.
+func sendStdinFrames(conn *websocket.Conn) {
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		if err := conn.WriteMessage(websocket.TextMessage, scanner.Bytes()); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestIsWebSocketURL(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want bool
+	}{
+		{"ws://example.com/socket", true},
+		{"wss://example.com/socket", true},
+		{"http://example.com", false},
+		{"https://example.com", false},
+	}
+
+	for _, tt := range tests {
+		u, err := url.Parse(tt.raw)
+		if err != nil {
+			t.Fatalf("url.Parse(%q): %v", tt.raw, err)
+		}
+		if got := isWebSocketURL(u); got != tt.want {
+			t.Fatalf("isWebSocketURL(%q) = %t, want %t", tt.raw, got, tt.want)
+		}
+	}
+}